@@ -0,0 +1,12 @@
+package stream
+
+import "github.com/hashicorp/nomad/acl"
+
+// ACLResolver resolves an ACL token to the Authorizer governing what it can
+// read. Subscription uses it to drop events the token is no longer
+// permitted to see, closing the gap where a subscription established under
+// a permissive token kept receiving events for objects it no longer has
+// rights to until the next token/policy change forced it closed.
+type ACLResolver interface {
+	ResolveToken(token string) (acl.Authorizer, error)
+}
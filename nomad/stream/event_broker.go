@@ -0,0 +1,301 @@
+package stream
+
+import (
+	"context"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// subjectBufferMaxItems bounds how many events an idle subject buffer will
+// retain before old items are dropped. Subject buffers are many and mostly
+// idle, so this is kept small relative to the old single-buffer-per-topic
+// default.
+const subjectBufferMaxItems = 512
+
+// EventBrokerCfg configures a new EventBroker.
+type EventBrokerCfg struct {
+	// EventBufferSize is the number of published events to keep in each
+	// subject buffer for late-attaching subscribers.
+	EventBufferSize int
+
+	// ACLResolver resolves a subscriber's token so Subscription.Next can
+	// drop events the token is no longer authorized to read. If nil, ACL
+	// filtering is skipped - callers embedding EventBroker somewhere
+	// already ACL-gated (e.g. behind an RPC that checked the token up
+	// front for a single-tenant use) can leave it unset.
+	ACLResolver ACLResolver
+}
+
+// EventBroker allows subscribers to subscribe to events, and publishers to
+// publish events. Events are organized first by topic, then by the
+// narrower Subject a subscriber cares about (e.g. one job, one node); this
+// lets a subscription attach to just the buffers it needs instead of
+// waking for every publish on the topic and filtering afterwards.
+type EventBroker struct {
+	mu sync.Mutex
+
+	// buffers holds one eventBuffer per (topic, subject) pair that has
+	// ever seen traffic. A topic's AllSubjects buffer is created by the
+	// first publish to that topic or the first subscription that attaches
+	// to it, whichever comes first.
+	buffers map[structs.Topic]map[Subject]*eventBuffer
+
+	subscriptions map[*Subscription]struct{}
+
+	bufferSize int
+
+	// snapshotHandlers let a topic be replayed from scratch for a
+	// subscriber whose requested Index has already aged out of the live
+	// buffer. snapshots shares in-flight and recent builds across
+	// subscribers asking for the same (topic, subject).
+	snapshotHandlers map[structs.Topic]SnapshotFunc
+	snapshots        *snapshotCache
+
+	aclResolver ACLResolver
+}
+
+// NewEventBroker returns a new EventBroker. Publish must be called to
+// populate it with an initial state; it starts out with no buffers until
+// either a publish or a subscribe for a topic occurs.
+func NewEventBroker(cfg EventBrokerCfg) *EventBroker {
+	size := cfg.EventBufferSize
+	if size <= 0 {
+		size = subjectBufferMaxItems
+	}
+	return &EventBroker{
+		buffers:          make(map[structs.Topic]map[Subject]*eventBuffer),
+		subscriptions:    make(map[*Subscription]struct{}),
+		bufferSize:       size,
+		snapshotHandlers: make(map[structs.Topic]SnapshotFunc),
+		snapshots:        newSnapshotCache(),
+		aclResolver:      cfg.ACLResolver,
+	}
+}
+
+// RegisterSnapshotHandler registers fn as the way to replay topic's
+// current state for a subscriber whose requested Index is no longer
+// covered by the live buffer. It must be called before any Subscribe for
+// topic that relies on it; it is not safe to call concurrently with
+// Subscribe.
+func (e *EventBroker) RegisterSnapshotHandler(topic structs.Topic, fn SnapshotFunc) {
+	e.snapshotHandlers[topic] = fn
+}
+
+// HasSnapshotHandler reports whether topic has a SnapshotFunc registered,
+// so callers fronting EventBroker (e.g. the gRPC endpoint) can decide
+// whether to warn a subscriber that a snapshot is about to be replayed
+// without duplicating Subscribe's own gating logic.
+func (e *EventBroker) HasSnapshotHandler(topic structs.Topic) bool {
+	_, ok := e.snapshotHandlers[topic]
+	return ok
+}
+
+// Publish appends events to the buffer(s) their Subject belongs to. An
+// event is always appended to its own subject buffer and, when that subject
+// is not AllSubjects, also to the topic's AllSubjects buffer and to the
+// namespace-less form of its subject -
+// the bucket a SubscribeRequest with Namespace == "" attaches to for that
+// key, per the namespace-wildcard convention filter() has always honored.
+// The full, unsplit batch is also appended to the broker-wide
+// structs.TopicAll/AllSubjects buffer, which is what a subscriber asking
+// for every topic attaches to.
+func (e *EventBroker) Publish(events *structs.Events) {
+	if events == nil || len(events.Events) == 0 {
+		return
+	}
+
+	byTopicSubject := make(map[structs.Topic]map[Subject][]structs.Event)
+	for _, event := range events.Events {
+		subject := eventSubject(event)
+		bySubject, ok := byTopicSubject[event.Topic]
+		if !ok {
+			bySubject = make(map[Subject][]structs.Event)
+			byTopicSubject[event.Topic] = bySubject
+		}
+		bySubject[subject] = append(bySubject[subject], event)
+		if subject != AllSubjects {
+			bySubject[AllSubjects] = append(bySubject[AllSubjects], event)
+			if keySubject := eventKeySubject(event); keySubject != subject {
+				bySubject[keySubject] = append(bySubject[keySubject], event)
+			}
+		}
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for topic, bySubject := range byTopicSubject {
+		for subject, subset := range bySubject {
+			e.bufferForTopicLocked(topic, subject).Append(&structs.Events{
+				Index:  events.Index,
+				Events: subset,
+			})
+		}
+	}
+	e.bufferForTopicLocked(structs.TopicAll, AllSubjects).Append(events)
+}
+
+// bufferForTopicLocked returns the eventBuffer for a (topic, subject)
+// pair, creating it if this is the first time the subject has been seen.
+// Callers must hold e.mu.
+func (e *EventBroker) bufferForTopicLocked(topic structs.Topic, subject Subject) *eventBuffer {
+	bySubject, ok := e.buffers[topic]
+	if !ok {
+		bySubject = make(map[Subject]*eventBuffer)
+		e.buffers[topic] = bySubject
+	}
+	buf, ok := bySubject[subject]
+	if !ok {
+		buf = newEventBuffer(e.bufferSize)
+		bySubject[subject] = buf
+	}
+	return buf
+}
+
+// Subscribe returns a new Subscription for the given request. The
+// subscription is attached to the subject buffer(s) that cover every
+// topic/key pair in the request; a request for a single job, for example,
+// attaches only to that job's buffer and never wakes for any other job's
+// events.
+//
+// If req.Index is older than what the matched buffers still hold and a
+// SnapshotFunc is registered for the request's topic, the subscription is
+// prefixed with a replayed snapshot terminated by EndOfSnapshot before it
+// continues on the live buffer.
+func (e *EventBroker) Subscribe(req *SubscribeRequest) (*Subscription, error) {
+	e.mu.Lock()
+
+	var topics []structs.Topic
+	var starts []*bufferItem
+	for topic := range req.Topics {
+		if topic == structs.TopicAll {
+			// A subscriber asking for every topic attaches to the
+			// broker-wide buffer every event is also appended to,
+			// rather than being skipped - EventBroker may not have
+			// created (or ever create) a buffer for every topic that
+			// will eventually publish.
+			starts = append(starts, e.bufferForTopicLocked(structs.TopicAll, AllSubjects).Head())
+			continue
+		}
+		topics = append(topics, topic)
+		for _, subject := range subjectsFor(req, topic) {
+			starts = append(starts, e.bufferForTopicLocked(topic, subject).Head())
+		}
+	}
+	e.mu.Unlock()
+
+	forceClosed := make(chan struct{})
+	start := mergeBufferHeads(starts, forceClosed)
+
+	if req.Index > 0 && len(topics) == 1 {
+		snapStart, err := e.snapshotChain(topics[0], req, start)
+		if err != nil {
+			return nil, err
+		}
+		start = snapStart
+	}
+
+	var sub *Subscription
+	sub = newSubscription(req, start, forceClosed, func() { e.unsubscribe(sub) }, e.aclResolver)
+
+	e.mu.Lock()
+	e.subscriptions[sub] = struct{}{}
+	e.mu.Unlock()
+
+	return sub, nil
+}
+
+// snapshotChain builds the bufferItem chain a subscriber should start on
+// when it needs to replay state before joining the live buffer: a single
+// item holding the snapshot events, followed by EndOfSnapshot, followed by
+// liveHead. If no SnapshotFunc is registered for topic, liveHead is
+// returned unchanged and the subscriber starts at the live head;
+// req.StartExactlyAtIndex is not consulted on either path.
+func (e *EventBroker) snapshotChain(topic structs.Topic, req *SubscribeRequest, liveHead *bufferItem) (*bufferItem, error) {
+	handler, ok := e.snapshotHandlers[topic]
+	if !ok {
+		return liveHead, nil
+	}
+
+	subject := snapshotSubjectKey(subjectsFor(req, topic))
+	events, err := e.snapshots.getOrBuild(snapshotCacheKey{topic: topic, subject: subject}, req, handler)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshotItem := &bufferItem{
+		Events: &structs.Events{Index: req.Index, Events: events},
+		link:   newBufferLink(),
+	}
+	eosItem := &bufferItem{
+		Events: &structs.Events{Index: req.Index, Events: []structs.Event{EndOfSnapshot}},
+		link:   newBufferLink(),
+	}
+	snapshotItem.append(eosItem)
+	eosItem.append(liveHead)
+	return snapshotItem, nil
+}
+
+// snapshotSubjectKey combines every subject a request resolves to for its
+// topic into one opaque cache key, so two requests whose key lists differ
+// (e.g. {web, db} vs {web, foo}) never collide on a shared snapshot just
+// because subjectsFor happens to order their first entry the same way.
+func snapshotSubjectKey(subjects []Subject) Subject {
+	if len(subjects) == 1 {
+		return subjects[0]
+	}
+	sorted := append([]Subject(nil), subjects...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	parts := make([]string, len(sorted))
+	for i, s := range sorted {
+		parts[i] = string(s)
+	}
+	return Subject(strings.Join(parts, "\x1f"))
+}
+
+func (e *EventBroker) unsubscribe(sub *Subscription) {
+	sub.forceClose()
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.subscriptions, sub)
+}
+
+// mergeBufferHeads returns a single bufferItem a Subscription can start
+// Next()-ing from. When a request attaches to more than one subject
+// buffer, one goroutine per buffer fans its events into a shared synthetic
+// chain as they arrive, so Subscription itself stays unaware that its
+// events may originate from several underlying buffers and an idle buffer
+// never blocks delivery of another's events. forceClosed is the
+// Subscription's own close signal: passing it into every underlying
+// Next() call is what lets every merge goroutine exit as soon as the
+// subscription is unsubscribed, instead of polling forever.
+func mergeBufferHeads(heads []*bufferItem, forceClosed chan struct{}) *bufferItem {
+	switch len(heads) {
+	case 0:
+		return newEventBuffer(1).Head()
+	case 1:
+		return heads[0]
+	}
+
+	merged := newEventBuffer(subjectBufferMaxItems)
+	var appendMu sync.Mutex
+	for _, h := range heads {
+		go func(cur *bufferItem) {
+			ctx := context.Background()
+			for {
+				next, err := cur.Next(ctx, forceClosed)
+				if err != nil {
+					return
+				}
+				cur = next
+				appendMu.Lock()
+				merged.Append(next.Events)
+				appendMu.Unlock()
+			}
+		}(h)
+	}
+	return merged.Head()
+}
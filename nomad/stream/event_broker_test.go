@@ -0,0 +1,146 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func testJobEvent(jobID string) structs.Event {
+	return structs.Event{
+		Topic:     structs.Topic("Job"),
+		Key:       jobID,
+		Namespace: "default",
+		Payload:   &structs.JobEvent{JobID: jobID, Namespace: "default"},
+	}
+}
+
+// waitForEvents polls NextNoBlock until it returns events or the deadline
+// passes, since EventBroker delivery happens on a buffer a publish away.
+func waitForEvents(t *testing.T, sub *Subscription) []structs.Event {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		events, err := sub.NextNoBlock()
+		if err != nil {
+			t.Fatalf("next: %v", err)
+		}
+		if events != nil {
+			return events
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("timed out waiting for events")
+	return nil
+}
+
+func TestEventBroker_Subscribe_PerSubjectRouting(t *testing.T) {
+	broker := NewEventBroker(EventBrokerCfg{})
+
+	sub, err := broker.Subscribe(&SubscribeRequest{
+		Namespace: "default",
+		Topics: map[structs.Topic][]string{
+			structs.Topic("Job"): {"web"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	broker.Publish(&structs.Events{Index: 1, Events: []structs.Event{testJobEvent("other")}})
+	broker.Publish(&structs.Events{Index: 2, Events: []structs.Event{testJobEvent("web")}})
+
+	events := waitForEvents(t, sub)
+	if len(events) != 1 || events[0].Key != "web" {
+		t.Fatalf("expected only the web job's event, got %+v", events)
+	}
+}
+
+func TestEventBroker_Subscribe_Wildcard(t *testing.T) {
+	broker := NewEventBroker(EventBrokerCfg{})
+
+	sub, err := broker.Subscribe(&SubscribeRequest{
+		Topics: map[structs.Topic][]string{structs.TopicAll: {string(structs.TopicAll)}},
+	})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	broker.Publish(&structs.Events{Index: 1, Events: []structs.Event{testJobEvent("web")}})
+
+	events := waitForEvents(t, sub)
+	if len(events) != 1 || events[0].Key != "web" {
+		t.Fatalf("expected the wildcard subscriber to see the published event, got %+v", events)
+	}
+}
+
+// TestEventBroker_Subscribe_NamespaceWildcard covers the pre-existing
+// filter() convention that an empty SubscribeRequest.Namespace matches a
+// key across every namespace - per-subject buffering must keep routing
+// such a subscription to something Publish actually writes into.
+func TestEventBroker_Subscribe_NamespaceWildcard(t *testing.T) {
+	broker := NewEventBroker(EventBrokerCfg{})
+
+	sub, err := broker.Subscribe(&SubscribeRequest{
+		Topics: map[structs.Topic][]string{
+			structs.Topic("Job"): {"web"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	broker.Publish(&structs.Events{Index: 1, Events: []structs.Event{testJobEvent("other")}})
+	broker.Publish(&structs.Events{Index: 2, Events: []structs.Event{testJobEvent("web")}})
+
+	events := waitForEvents(t, sub)
+	if len(events) != 1 || events[0].Key != "web" {
+		t.Fatalf("expected a namespace-wildcard subscriber to see the web job's event across namespaces, got %+v", events)
+	}
+}
+
+// TestEventBroker_MergeBufferHeads_NoStarvation covers the starvation bug
+// where a strictly round-robin merge of several subject buffers would
+// block delivering one buffer's events behind another, idle, one.
+func TestEventBroker_MergeBufferHeads_NoStarvation(t *testing.T) {
+	broker := NewEventBroker(EventBrokerCfg{})
+
+	sub, err := broker.Subscribe(&SubscribeRequest{
+		Namespace: "default",
+		Topics: map[structs.Topic][]string{
+			structs.Topic("Job"):  {"web", "db"},
+			structs.Topic("Node"): {"n1"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	// Node never publishes again; Job keeps producing. A round-robin
+	// merge blocked forever on Node's idle head before ever surfacing
+	// these.
+	for i := 0; i < 3; i++ {
+		broker.Publish(&structs.Events{Index: uint64(i + 1), Events: []structs.Event{testJobEvent("web")}})
+	}
+
+	seen := 0
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) && seen < 3 {
+		events, err := sub.NextNoBlock()
+		if err != nil {
+			t.Fatalf("next: %v", err)
+		}
+		seen += len(events)
+		if events == nil {
+			time.Sleep(time.Millisecond)
+		}
+	}
+	if seen != 3 {
+		t.Fatalf("expected all 3 Job events to be delivered despite Node's buffer being idle, got %d", seen)
+	}
+}
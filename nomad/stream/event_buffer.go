@@ -0,0 +1,145 @@
+package stream
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// bufferItem is a node in a singly linked list of structs.Events. Once its
+// link is set it is immutable, which lets subscribers walk the list
+// concurrently with the publisher appending to it without taking a lock.
+type bufferItem struct {
+	Events *structs.Events
+
+	// seq is this item's position in its eventBuffer, starting at 0 for
+	// the sentinel item newEventBuffer creates. It lets Next tell how far
+	// behind the live head a caller's current item has fallen.
+	seq uint64
+
+	// buf is the eventBuffer this item belongs to, used only to read its
+	// current head and maxItems when deciding whether a caller has fallen
+	// too far behind to keep walking the chain. Nil for items that were
+	// never appended to an eventBuffer (e.g. the snapshot and EndOfSnapshot
+	// items EventBroker.snapshotChain builds).
+	buf *eventBuffer
+
+	// link holds the next *bufferItem once it has been appended. It is
+	// written exactly once via atomic.Value so concurrent readers never
+	// observe a torn pointer.
+	link bufferLink
+}
+
+// bufferLink is the mutable tail of a bufferItem: a next pointer plus a
+// channel that is closed when next is set, so that Next can either read
+// the pointer directly (fast path) or block on the channel (slow path).
+type bufferLink struct {
+	next   atomic.Value // *bufferItem
+	ready  chan struct{}
+}
+
+func newBufferLink() bufferLink {
+	return bufferLink{ready: make(chan struct{})}
+}
+
+// append sets the next item in the chain. It must only be called once per
+// bufferItem. eventBuffer itself takes no lock: callers of Append must
+// serialize their calls (EventBroker.Publish holds the broker's mutex,
+// mergeBufferHeads its own).
+func (i *bufferItem) append(next *bufferItem) {
+	i.link.next.Store(next)
+	close(i.link.ready)
+}
+
+// Next returns the next bufferItem in the chain, blocking until it is
+// appended, ctx is cancelled, or forceClosed is closed. If i has fallen
+// more than its buffer's maxItems behind the live head, it returns
+// ErrSubscriptionClosed instead of truncated-away data, forcing the caller
+// to resync with a fresh Subscribe.
+func (i *bufferItem) Next(ctx context.Context, forceClosed chan struct{}) (*bufferItem, error) {
+	if i.tooFarBehind() {
+		return nil, ErrSubscriptionClosed
+	}
+	if next := i.link.next.Load(); next != nil {
+		return next.(*bufferItem), nil
+	}
+
+	select {
+	case <-i.link.ready:
+		return i.link.next.Load().(*bufferItem), nil
+	case <-forceClosed:
+		return nil, ErrSubscriptionClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// NextNoBlock returns the next bufferItem in the chain, or nil if none has
+// been appended yet. It returns ErrSubscriptionClosed under the same
+// too-far-behind condition Next does.
+func (i *bufferItem) NextNoBlock() (*bufferItem, error) {
+	if i.tooFarBehind() {
+		return nil, ErrSubscriptionClosed
+	}
+	next := i.link.next.Load()
+	if next == nil {
+		return nil, nil
+	}
+	return next.(*bufferItem), nil
+}
+
+// tooFarBehind reports whether i is more than its buffer's maxItems behind
+// the buffer's current head, i.e. whether continuing to read forward from
+// here would mean resuming a backlog the buffer no longer guarantees it
+// can deliver in full.
+func (i *bufferItem) tooFarBehind() bool {
+	if i.buf == nil || i.buf.maxItems <= 0 {
+		return false
+	}
+	head := i.buf.Head()
+	return head.seq-i.seq > uint64(i.buf.maxItems)
+}
+
+// eventBuffer is an append-only chain of bufferItems for a single (topic,
+// subject) pair, bounded to maxItems behind its head (see maxItems).
+// EventBroker keeps one eventBuffer per subject it has seen traffic for,
+// plus one for AllSubjects, rather than a single buffer per topic - this is
+// what lets a subscription watching one job skip the events of every
+// other job instead of filtering them out after waking up.
+type eventBuffer struct {
+	head atomic.Value // *bufferItem
+
+	// maxItems bounds how far behind the live head a subscriber can fall
+	// before it is cut off: once a caller's current item is more than
+	// maxItems appends stale, bufferItem.Next/NextNoBlock return
+	// ErrSubscriptionClosed instead of letting it keep walking the
+	// backlog, forcing it to resync with a fresh Subscribe. This is what
+	// bounds memory for a subscriber that stops reading - once it falls
+	// behind far enough, nothing keeps the abandoned prefix of the chain
+	// reachable once it resyncs. maxItems <= 0 disables the check.
+	maxItems int
+}
+
+// newEventBuffer returns an eventBuffer primed with a single sentinel
+// bufferItem so subscribers always have a valid starting point to call
+// Next on.
+func newEventBuffer(maxItems int) *eventBuffer {
+	b := &eventBuffer{maxItems: maxItems}
+	b.head.Store(&bufferItem{Events: &structs.Events{}, buf: b, link: newBufferLink()})
+	return b
+}
+
+// Head returns the current latest bufferItem, suitable for a subscriber
+// that wants to start receiving events from now on.
+func (b *eventBuffer) Head() *bufferItem {
+	return b.head.Load().(*bufferItem)
+}
+
+// Append adds events to the end of the buffer, publishing a new head.
+func (b *eventBuffer) Append(events *structs.Events) {
+	prev := b.Head()
+	item := &bufferItem{Events: events, seq: prev.seq + 1, buf: b, link: newBufferLink()}
+	prev.append(item)
+	b.head.Store(item)
+}
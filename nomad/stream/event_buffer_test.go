@@ -0,0 +1,37 @@
+package stream
+
+import (
+	"context"
+	"testing"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+func TestEventBuffer_TruncatesFarBehindReaders(t *testing.T) {
+	buf := newEventBuffer(2)
+	start := buf.Head()
+
+	for i := 0; i < 5; i++ {
+		buf.Append(&structs.Events{Index: uint64(i + 1)})
+	}
+
+	// start is now 5 appends behind a buffer that only guarantees 2, so
+	// walking forward from it must report ErrSubscriptionClosed instead
+	// of silently resuming the backlog.
+	if _, err := start.Next(context.Background(), nil); err != ErrSubscriptionClosed {
+		t.Fatalf("expected ErrSubscriptionClosed for a reader that fell behind, got %v", err)
+	}
+	if _, err := start.NextNoBlock(); err != ErrSubscriptionClosed {
+		t.Fatalf("expected ErrSubscriptionClosed from NextNoBlock for a reader that fell behind, got %v", err)
+	}
+
+	// A reader that stayed within maxItems of the head is unaffected.
+	recent := buf.Head()
+	item, err := recent.NextNoBlock()
+	if err != nil {
+		t.Fatalf("unexpected error for a reader at the head: %v", err)
+	}
+	if item != nil {
+		t.Fatalf("expected nil (no new item yet) from the head, got %+v", item)
+	}
+}
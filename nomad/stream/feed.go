@@ -0,0 +1,137 @@
+package stream
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// FeedEvent pairs a batch of events with the id of the Add call (see
+// Feed.Add) whose filter produced them, so a caller multiplexing many
+// dynamic filters on one connection can tell which one matched.
+type FeedEvent struct {
+	ID     uint64
+	Events structs.Events
+}
+
+// Feed multiplexes any number of dynamically added and removed
+// subscriptions against a single EventBroker onto one channel. It is
+// modeled on go-ethereum's event.Feed/SubscriptionScope pattern: a client
+// opens one Feed bound to a token, calls Add to start watching a
+// topic/key/namespace filter and Remove to stop, and reads every matching
+// event off Events tagged with the id Add returned. Closing the Feed
+// cancels every child subscription atomically, the way closing a
+// SubscriptionScope does.
+type Feed struct {
+	broker *EventBroker
+	token  string
+
+	// Events carries every event from every child subscription, tagged
+	// with the id of the Add call that created it.
+	Events chan FeedEvent
+
+	mu      sync.Mutex
+	closed  bool
+	nextID  uint64
+	cancels map[uint64]context.CancelFunc
+}
+
+// NewFeed returns a Feed with no subscriptions yet, bound to token for
+// every filter added via Add.
+func NewFeed(broker *EventBroker, token string) *Feed {
+	return &Feed{
+		broker:  broker,
+		token:   token,
+		Events:  make(chan FeedEvent, 16),
+		cancels: make(map[uint64]context.CancelFunc),
+	}
+}
+
+// Add starts a new child subscription for the given topics/keys/namespace
+// and returns its id, which tags every FeedEvent it produces and is later
+// passed to Remove to stop it. Adding or removing a filter never disturbs
+// any of the Feed's other subscriptions.
+func (f *Feed) Add(topics map[structs.Topic][]string, namespace string) (uint64, error) {
+	sub, err := f.broker.Subscribe(&SubscribeRequest{
+		Token:     f.token,
+		Namespace: namespace,
+		Topics:    topics,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	f.mu.Lock()
+	if f.closed {
+		f.mu.Unlock()
+		cancel()
+		sub.Unsubscribe()
+		return 0, ErrSubscriptionClosed
+	}
+	id := f.nextID
+	f.nextID++
+	f.cancels[id] = cancel
+	f.mu.Unlock()
+
+	go f.pump(ctx, id, sub)
+	return id, nil
+}
+
+// Remove stops the subscription id previously returned by Add. It is a
+// no-op if id is unknown or was already removed.
+func (f *Feed) Remove(id uint64) {
+	f.mu.Lock()
+	cancel, ok := f.cancels[id]
+	if ok {
+		delete(f.cancels, id)
+	}
+	f.mu.Unlock()
+
+	if ok {
+		cancel()
+	}
+}
+
+// pump forwards sub's events onto f.Events tagged with id until ctx is
+// cancelled, by Remove or by Close, or the subscription closes on its own.
+func (f *Feed) pump(ctx context.Context, id uint64, sub *Subscription) {
+	defer sub.Unsubscribe()
+
+	for {
+		events, err := sub.Next(ctx)
+		if err != nil {
+			return
+		}
+		// Next can return an already-appended item after ctx is cancelled;
+		// never forward it once Remove or Close has run.
+		if ctx.Err() != nil {
+			return
+		}
+
+		select {
+		case f.Events <- FeedEvent{ID: id, Events: events}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Close cancels every child subscription the Feed currently holds and
+// prevents any further Add calls from succeeding. It does not close
+// Events, since a pump goroutine may still be mid-send when Close returns;
+// callers that need to know every goroutine has exited should drain
+// Events until it naturally goes quiet.
+func (f *Feed) Close() {
+	f.mu.Lock()
+	f.closed = true
+	cancels := f.cancels
+	f.cancels = make(map[uint64]context.CancelFunc)
+	f.mu.Unlock()
+
+	for _, cancel := range cancels {
+		cancel()
+	}
+}
@@ -0,0 +1,77 @@
+package stream
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// waitForFeedEvent reads one FeedEvent off f.Events or fails the test if
+// none arrives before the deadline.
+func waitForFeedEvent(t *testing.T, f *Feed) FeedEvent {
+	t.Helper()
+	select {
+	case ev := <-f.Events:
+		return ev
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for feed event")
+		return FeedEvent{}
+	}
+}
+
+func TestFeed_Add(t *testing.T) {
+	broker := NewEventBroker(EventBrokerCfg{})
+	feed := NewFeed(broker, "")
+	defer feed.Close()
+
+	id, err := feed.Add(map[structs.Topic][]string{structs.Topic("Job"): {"web"}}, "default")
+	if err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	broker.Publish(&structs.Events{Index: 1, Events: []structs.Event{testJobEvent("web")}})
+
+	ev := waitForFeedEvent(t, feed)
+	if ev.ID != id {
+		t.Fatalf("expected event tagged with id %d, got %d", id, ev.ID)
+	}
+	if len(ev.Events.Events) != 1 || ev.Events.Events[0].Key != "web" {
+		t.Fatalf("expected the web job's event, got %+v", ev.Events.Events)
+	}
+}
+
+func TestFeed_Remove(t *testing.T) {
+	broker := NewEventBroker(EventBrokerCfg{})
+	feed := NewFeed(broker, "")
+	defer feed.Close()
+
+	id, err := feed.Add(map[structs.Topic][]string{structs.Topic("Job"): {"web"}}, "default")
+	if err != nil {
+		t.Fatalf("add: %v", err)
+	}
+	feed.Remove(id)
+
+	broker.Publish(&structs.Events{Index: 1, Events: []structs.Event{testJobEvent("web")}})
+
+	select {
+	case ev := <-feed.Events:
+		t.Fatalf("expected no events after Remove, got %+v", ev)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestFeed_Close(t *testing.T) {
+	broker := NewEventBroker(EventBrokerCfg{})
+	feed := NewFeed(broker, "")
+
+	if _, err := feed.Add(map[structs.Topic][]string{structs.Topic("Job"): {"web"}}, "default"); err != nil {
+		t.Fatalf("add: %v", err)
+	}
+
+	feed.Close()
+
+	if _, err := feed.Add(map[structs.Topic][]string{structs.Topic("Job"): {"web"}}, "default"); err != ErrSubscriptionClosed {
+		t.Fatalf("expected Add after Close to fail with ErrSubscriptionClosed, got %v", err)
+	}
+}
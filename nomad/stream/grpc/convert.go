@@ -0,0 +1,53 @@
+package grpc
+
+import (
+	"github.com/hashicorp/nomad/nomad/stream"
+	"github.com/hashicorp/nomad/nomad/stream/grpc/pb"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// convertRequest maps a protobuf SubscribeRequest onto the stream
+// package's SubscribeRequest. token is the value resolved from call
+// metadata, which always wins over req.Token.
+func convertRequest(req *pb.SubscribeRequest, token string) *stream.SubscribeRequest {
+	topics := make(map[structs.Topic][]string, len(req.Topics))
+	for topic, keys := range req.Topics {
+		k := keys.GetKeys()
+		if len(k) == 0 {
+			// An empty Keys list selects every key in the topic.
+			k = []string{string(structs.TopicAll)}
+		}
+		topics[structs.Topic(topic)] = k
+	}
+
+	return &stream.SubscribeRequest{
+		Token:               token,
+		Index:               req.Index,
+		Namespace:           req.Namespace,
+		Topics:              topics,
+		StartExactlyAtIndex: req.StartExactlyAtIndex,
+	}
+}
+
+// convertEvents maps a batch of stream events onto the wire type, encoding
+// each payload with the same msgpack codec used elsewhere for RPC structs.
+func convertEvents(events structs.Events) (*pb.Events, error) {
+	out := &pb.Events{
+		Index:  events.Index,
+		Events: make([]*pb.Event, 0, len(events.Events)),
+	}
+	for _, e := range events.Events {
+		payload, err := structs.Encode(e.Payload)
+		if err != nil {
+			return nil, err
+		}
+		out.Events = append(out.Events, &pb.Event{
+			Topic:     string(e.Topic),
+			Key:       e.Key,
+			Namespace: e.Namespace,
+			Index:     events.Index,
+			Payload:   payload,
+		})
+	}
+	return out, nil
+}
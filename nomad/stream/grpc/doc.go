@@ -0,0 +1,5 @@
+// Package grpc exposes a nomad/stream.EventBroker over a gRPC
+// EventService defined in subscribe.proto.
+package grpc
+
+//go:generate protoc --go_out=pb --go-grpc_out=pb --go_opt=paths=source_relative --go-grpc_opt=paths=source_relative subscribe.proto
@@ -0,0 +1,159 @@
+// Package pb holds the message types for subscribe.proto's EventService.
+//
+// This file is hand-maintained, not protoc-gen-go output: it implements
+// only the pre-APIv2 proto.Message trio (Reset/String/ProtoMessage) that
+// convert.go and subscribe_endpoint.go actually call, and has none of
+// protoc-gen-go v1.31's ProtoReflect()/raw descriptor/protoimpl wiring, so
+// it will not work with grpc reflection, protojson, or anything else that
+// needs real reflection over the message. Running the //go:generate
+// directive in ../doc.go replaces it with the genuine generated package;
+// until then, keep this in sync with subscribe.proto by hand.
+package pb
+
+type SubscribeRequest struct {
+	Topics              map[string]*Keys `protobuf:"bytes,1,rep,name=topics,proto3" json:"topics,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Namespace           string           `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Index               uint64           `protobuf:"varint,3,opt,name=index,proto3" json:"index,omitempty"`
+	StartExactlyAtIndex bool             `protobuf:"varint,4,opt,name=start_exactly_at_index,json=startExactlyAtIndex,proto3" json:"start_exactly_at_index,omitempty"`
+	Token               string           `protobuf:"bytes,5,opt,name=token,proto3" json:"token,omitempty"`
+}
+
+func (x *SubscribeRequest) Reset()         { *x = SubscribeRequest{} }
+func (x *SubscribeRequest) String() string { return "" }
+func (*SubscribeRequest) ProtoMessage()     {}
+
+func (x *SubscribeRequest) GetTopics() map[string]*Keys {
+	if x != nil {
+		return x.Topics
+	}
+	return nil
+}
+
+func (x *SubscribeRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *SubscribeRequest) GetIndex() uint64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *SubscribeRequest) GetStartExactlyAtIndex() bool {
+	if x != nil {
+		return x.StartExactlyAtIndex
+	}
+	return false
+}
+
+func (x *SubscribeRequest) GetToken() string {
+	if x != nil {
+		return x.Token
+	}
+	return ""
+}
+
+type Keys struct {
+	Keys []string `protobuf:"bytes,1,rep,name=keys,proto3" json:"keys,omitempty"`
+}
+
+func (x *Keys) Reset()         { *x = Keys{} }
+func (x *Keys) String() string { return "" }
+func (*Keys) ProtoMessage()    {}
+
+func (x *Keys) GetKeys() []string {
+	if x != nil {
+		return x.Keys
+	}
+	return nil
+}
+
+type Events struct {
+	Index               uint64   `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Events              []*Event `protobuf:"bytes,2,rep,name=events,proto3" json:"events,omitempty"`
+	Heartbeat           bool     `protobuf:"varint,3,opt,name=heartbeat,proto3" json:"heartbeat,omitempty"`
+	NewSnapshotToFollow bool     `protobuf:"varint,4,opt,name=new_snapshot_to_follow,json=newSnapshotToFollow,proto3" json:"new_snapshot_to_follow,omitempty"`
+}
+
+func (x *Events) Reset()         { *x = Events{} }
+func (x *Events) String() string { return "" }
+func (*Events) ProtoMessage()    {}
+
+func (x *Events) GetIndex() uint64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *Events) GetEvents() []*Event {
+	if x != nil {
+		return x.Events
+	}
+	return nil
+}
+
+func (x *Events) GetHeartbeat() bool {
+	if x != nil {
+		return x.Heartbeat
+	}
+	return false
+}
+
+func (x *Events) GetNewSnapshotToFollow() bool {
+	if x != nil {
+		return x.NewSnapshotToFollow
+	}
+	return false
+}
+
+type Event struct {
+	Topic     string `protobuf:"bytes,1,opt,name=topic,proto3" json:"topic,omitempty"`
+	Key       string `protobuf:"bytes,2,opt,name=key,proto3" json:"key,omitempty"`
+	Namespace string `protobuf:"bytes,3,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	Index     uint64 `protobuf:"varint,4,opt,name=index,proto3" json:"index,omitempty"`
+	Payload   []byte `protobuf:"bytes,5,opt,name=payload,proto3" json:"payload,omitempty"`
+}
+
+func (x *Event) Reset()         { *x = Event{} }
+func (x *Event) String() string { return "" }
+func (*Event) ProtoMessage()    {}
+
+func (x *Event) GetTopic() string {
+	if x != nil {
+		return x.Topic
+	}
+	return ""
+}
+
+func (x *Event) GetKey() string {
+	if x != nil {
+		return x.Key
+	}
+	return ""
+}
+
+func (x *Event) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *Event) GetIndex() uint64 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *Event) GetPayload() []byte {
+	if x != nil {
+		return x.Payload
+	}
+	return nil
+}
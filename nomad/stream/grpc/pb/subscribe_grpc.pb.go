@@ -0,0 +1,123 @@
+// This file is hand-maintained, not protoc-gen-go-grpc output - see the
+// package doc in subscribe.pb.go. It implements the same client/server
+// surface real protoc-gen-go-grpc v1.3 output would for subscribe.proto's
+// EventService, minus anything that depends on subscribe.pb.go's missing
+// ProtoReflect() support.
+
+package pb
+
+import (
+	context "context"
+
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+const EventService_Subscribe_FullMethodName = "/hashicorp.nomad.stream.grpc.EventService/Subscribe"
+
+// EventServiceClient is the client API for EventService service.
+type EventServiceClient interface {
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (EventService_SubscribeClient, error)
+}
+
+type eventServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEventServiceClient(cc grpc.ClientConnInterface) EventServiceClient {
+	return &eventServiceClient{cc}
+}
+
+func (c *eventServiceClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (EventService_SubscribeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &EventService_ServiceDesc.Streams[0], EventService_Subscribe_FullMethodName, opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &eventServiceSubscribeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// EventService_SubscribeClient is the client-side stream returned by
+// EventServiceClient.Subscribe.
+type EventService_SubscribeClient interface {
+	Recv() (*Events, error)
+	grpc.ClientStream
+}
+
+type eventServiceSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *eventServiceSubscribeClient) Recv() (*Events, error) {
+	m := new(Events)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// EventServiceServer is the server API for EventService service.
+type EventServiceServer interface {
+	Subscribe(*SubscribeRequest, EventService_SubscribeServer) error
+	mustEmbedUnimplementedEventServiceServer()
+}
+
+// UnimplementedEventServiceServer must be embedded for forward
+// compatibility.
+type UnimplementedEventServiceServer struct{}
+
+func (UnimplementedEventServiceServer) Subscribe(*SubscribeRequest, EventService_SubscribeServer) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (UnimplementedEventServiceServer) mustEmbedUnimplementedEventServiceServer() {}
+
+func RegisterEventServiceServer(s grpc.ServiceRegistrar, srv EventServiceServer) {
+	s.RegisterService(&EventService_ServiceDesc, srv)
+}
+
+func _EventService_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(EventServiceServer).Subscribe(m, &eventServiceSubscribeServer{stream})
+}
+
+// EventService_SubscribeServer is the server-side stream passed to
+// EventServiceServer.Subscribe.
+type EventService_SubscribeServer interface {
+	Send(*Events) error
+	grpc.ServerStream
+}
+
+type eventServiceSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *eventServiceSubscribeServer) Send(m *Events) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// EventService_ServiceDesc is the grpc.ServiceDesc for EventService
+// service. It's used by grpc.NewServer, and is not meant to be used
+// directly.
+var EventService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "hashicorp.nomad.stream.grpc.EventService",
+	HandlerType: (*EventServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _EventService_Subscribe_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "subscribe.proto",
+}
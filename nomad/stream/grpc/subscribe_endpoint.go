@@ -0,0 +1,150 @@
+package grpc
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/nomad/nomad/stream"
+	"github.com/hashicorp/nomad/nomad/stream/grpc/pb"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// tokenMetadataKey is the gRPC metadata key clients set their ACL token
+// under, mirroring the "X-Nomad-Token" HTTP header used by the existing
+// /v1/event/stream endpoint.
+const tokenMetadataKey = "x-nomad-token"
+
+// heartbeatInterval bounds how long a subscriber can go without a frame
+// before Server sends an empty heartbeat frame anyway, so intermediate
+// proxies and load balancers don't time the stream out while the
+// subscription is otherwise idle.
+const heartbeatInterval = 30 * time.Second
+
+// Server implements pb.EventServiceServer on top of an EventBroker.
+type Server struct {
+	pb.UnimplementedEventServiceServer
+
+	broker *stream.EventBroker
+}
+
+// NewServer returns a Server that streams events from broker.
+func NewServer(broker *stream.EventBroker) *Server {
+	return &Server{broker: broker}
+}
+
+// Subscribe implements pb.EventServiceServer. It ACL-enforces using the
+// token from call metadata, maps req onto EventBroker.Subscribe, and
+// streams framed Events back until the client's context is cancelled or
+// the subscription is force-closed - at which point ErrSubscriptionClosed
+// is translated to codes.Aborted so well-behaved clients know to
+// resubscribe rather than treat it as a terminal error.
+func (s *Server) Subscribe(req *pb.SubscribeRequest, srv pb.EventService_SubscribeServer) error {
+	ctx := srv.Context()
+
+	sub, err := s.broker.Subscribe(convertRequest(req, tokenFromContext(ctx, req.Token)))
+	if err != nil {
+		return status.Errorf(codes.Internal, "subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	// Mirror the gating EventBroker.Subscribe/snapshotChain use to decide
+	// whether a snapshot will actually be chained in front of the live
+	// buffer: a single requested topic with a registered snapshot handler.
+	// Anything else and req.Index > 0 just means an early replay from the
+	// live buffer, not a snapshot.
+	if topic, ok := singleTopic(req.Topics); ok && req.Index > 0 && s.broker.HasSnapshotHandler(topic) {
+		if err := srv.Send(&pb.Events{NewSnapshotToFollow: true}); err != nil {
+			return err
+		}
+	}
+
+	return s.forward(ctx, sub, srv)
+}
+
+// singleTopic reports the lone topic in topics other than TopicAll, if
+// there is exactly one - the same count EventBroker.Subscribe uses.
+func singleTopic(topics map[string]*pb.Keys) (structs.Topic, bool) {
+	var found structs.Topic
+	n := 0
+	for t := range topics {
+		if structs.Topic(t) == structs.TopicAll {
+			continue
+		}
+		found = structs.Topic(t)
+		n++
+	}
+	return found, n == 1
+}
+
+// forward drains sub and sends frames on srv, interleaving heartbeat
+// frames whenever the subscription has been idle for heartbeatInterval.
+func (s *Server) forward(ctx context.Context, sub *stream.Subscription, srv pb.EventService_SubscribeServer) error {
+	type result struct {
+		events structs.Events
+		err    error
+	}
+	nextCh := make(chan result, 1)
+	fetch := func() {
+		events, err := sub.Next(ctx)
+		nextCh <- result{events, err}
+	}
+	go fetch()
+
+	timer := time.NewTimer(heartbeatInterval)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case <-timer.C:
+			if err := srv.Send(&pb.Events{Heartbeat: true}); err != nil {
+				return err
+			}
+			timer.Reset(heartbeatInterval)
+
+		case r := <-nextCh:
+			switch {
+			case r.err == stream.ErrSubscriptionClosed:
+				return status.Error(codes.Aborted, stream.ErrSubscriptionClosed.Error())
+			case r.err != nil:
+				return status.Errorf(codes.Internal, "subscribe: %v", r.err)
+			}
+
+			out, err := convertEvents(r.events)
+			if err != nil {
+				return status.Errorf(codes.Internal, "encode events: %v", err)
+			}
+			if err := srv.Send(out); err != nil {
+				return err
+			}
+
+			if !timer.Stop() {
+				<-timer.C
+			}
+			timer.Reset(heartbeatInterval)
+			go fetch()
+		}
+	}
+}
+
+// tokenFromContext prefers the token carried in call metadata over the one
+// embedded in the request message, so a long-lived stream always uses the
+// token the transport authenticated rather than one a client could forge
+// in the request body.
+func tokenFromContext(ctx context.Context, fallback string) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return fallback
+	}
+	values := md.Get(tokenMetadataKey)
+	if len(values) == 0 {
+		return fallback
+	}
+	return values[0]
+}
@@ -0,0 +1,96 @@
+package stream
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// endOfSnapshotTopic frames the transition from a replayed snapshot to the
+// live event buffer. Subscription.Next always returns it to the caller,
+// bypassing the normal topic/key filter, so a caller knows exactly when
+// its local state has caught up with the server's.
+const endOfSnapshotTopic structs.Topic = "EndOfSnapshot"
+
+// EndOfSnapshot is appended by EventBroker after a SnapshotFunc has
+// finished streaming the current state for a SubscribeRequest.
+var EndOfSnapshot = structs.Event{Topic: endOfSnapshotTopic}
+
+// SnapshotFunc produces the events representing the current state
+// relevant to req (e.g. every live allocation for a job) and hands them to
+// appendFn, possibly in more than one batch for large snapshots. It is
+// registered per topic so that a SubscribeRequest whose Index is older
+// than anything left in the live buffer can still be satisfied, instead of
+// StartExactlyAtIndex simply failing.
+type SnapshotFunc func(req *SubscribeRequest, appendFn func(events []structs.Event)) error
+
+// snapshotCacheTTL bounds how long a built snapshot is shared between
+// subscribers asking for the same (topic, subject) before it is rebuilt.
+// This keeps a thundering herd of reconnects after an outage - e.g. every
+// subscriber to one job's deployment - from building the snapshot once
+// per subscriber.
+const snapshotCacheTTL = 10 * time.Second
+
+type snapshotCacheKey struct {
+	topic   structs.Topic
+	subject Subject
+}
+
+// snapshotEntry holds a built (or building) snapshot. building is true
+// from the moment the entry is registered until the build finishes; done
+// is closed at that same point, so concurrent callers for the same key
+// that see building or a fresh builtAt block on done rather than each
+// triggering their own build.
+type snapshotEntry struct {
+	events   []structs.Event
+	err      error
+	builtAt  time.Time
+	building bool
+	done     chan struct{}
+}
+
+// snapshotCache shares in-flight and recently built snapshots across
+// subscribers. It is owned by EventBroker and keyed by (topic, subject) so
+// that, e.g., every subscriber reconnecting to the same job's event stream
+// shares one snapshot build.
+type snapshotCache struct {
+	mu      sync.Mutex
+	entries map[snapshotCacheKey]*snapshotEntry
+}
+
+func newSnapshotCache() *snapshotCache {
+	return &snapshotCache{entries: make(map[snapshotCacheKey]*snapshotEntry)}
+}
+
+// getOrBuild returns the events for key, building them with fn if there is
+// no unexpired entry yet. A build already in progress is always shared,
+// regardless of how stale the previous completed build was - checking
+// builtAt alone would make every concurrent caller see the zero value
+// while the first build is still running and each kick off its own.
+func (c *snapshotCache) getOrBuild(key snapshotCacheKey, req *SubscribeRequest, fn SnapshotFunc) ([]structs.Event, error) {
+	c.mu.Lock()
+	if entry, ok := c.entries[key]; ok && (entry.building || time.Since(entry.builtAt) < snapshotCacheTTL) {
+		c.mu.Unlock()
+		<-entry.done
+		return entry.events, entry.err
+	}
+
+	entry := &snapshotEntry{done: make(chan struct{}), building: true}
+	c.entries[key] = entry
+	c.mu.Unlock()
+
+	var events []structs.Event
+	err := fn(req, func(batch []structs.Event) {
+		events = append(events, batch...)
+	})
+
+	c.mu.Lock()
+	entry.events = events
+	entry.err = err
+	entry.builtAt = time.Now()
+	entry.building = false
+	c.mu.Unlock()
+	close(entry.done)
+	return events, err
+}
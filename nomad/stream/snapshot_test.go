@@ -0,0 +1,81 @@
+package stream
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// TestSnapshotCache_CoalescesConcurrentBuilds asserts that N concurrent
+// getOrBuild calls for the same key share one SnapshotFunc invocation
+// rather than each building their own snapshot.
+func TestSnapshotCache_CoalescesConcurrentBuilds(t *testing.T) {
+	cache := newSnapshotCache()
+	key := snapshotCacheKey{topic: structs.Topic("Job"), subject: Subject("web")}
+
+	var calls int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	fn := func(req *SubscribeRequest, appendFn func([]structs.Event)) error {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			close(started)
+			<-release
+		}
+		appendFn([]structs.Event{testJobEvent("web")})
+		return nil
+	}
+
+	const subscribers = 5
+	var wg sync.WaitGroup
+	results := make([][]structs.Event, subscribers)
+	for i := 0; i < subscribers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			events, err := cache.getOrBuild(key, &SubscribeRequest{}, fn)
+			if err != nil {
+				t.Errorf("getOrBuild: %v", err)
+			}
+			results[i] = events
+		}(i)
+	}
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the snapshot build to start")
+	}
+	close(release)
+	wg.Wait()
+
+	if got := atomic.LoadInt32(&calls); got != 1 {
+		t.Fatalf("expected exactly one SnapshotFunc call, got %d", got)
+	}
+	for i, events := range results {
+		if len(events) != 1 || events[0].Key != "web" {
+			t.Fatalf("subscriber %d got unexpected events: %+v", i, events)
+		}
+	}
+}
+
+// TestSnapshotSubjectKey_DistinguishesKeySets asserts that two multi-key
+// requests sharing a first key but differing afterward never collide on
+// the same snapshot cache key.
+func TestSnapshotSubjectKey_DistinguishesKeySets(t *testing.T) {
+	a := snapshotSubjectKey([]Subject{NewSubject("default", "web"), NewSubject("default", "db")})
+	b := snapshotSubjectKey([]Subject{NewSubject("default", "web"), NewSubject("default", "foo")})
+	if a == b {
+		t.Fatalf("expected different key sets to produce different cache keys, both got %q", a)
+	}
+
+	// Order shouldn't matter - the same set of keys must land on the same
+	// cache entry regardless of the order subjectsFor happened to resolve
+	// them in.
+	c := snapshotSubjectKey([]Subject{NewSubject("default", "db"), NewSubject("default", "web")})
+	if a != c {
+		t.Fatalf("expected the same key set to produce the same cache key regardless of order, got %q and %q", a, c)
+	}
+}
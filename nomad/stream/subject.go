@@ -0,0 +1,102 @@
+package stream
+
+import "github.com/hashicorp/nomad/nomad/structs"
+
+// AllSubjects is the wildcard Subject. Events published under AllSubjects
+// are delivered to every subscriber of the topic regardless of which
+// subject buffer they attached to, and subscriptions that did not ask for
+// a specific key (e.g. Topics[t] contains structs.TopicAll) attach to the
+// AllSubjects buffer so they keep seeing the full stream for that topic.
+const AllSubjects Subject = ""
+
+// Subject is an opaque key EventBroker uses to route an event to the
+// narrowest buffer a subscriber could be attached to. It is derived from a
+// payload's namespace and natural key (job ID, node ID, alloc ID, ...) so
+// that, for example, a subscription watching a single job never has to
+// wake for events belonging to any other job.
+//
+// Subject values are only ever compared for equality or sorted (to build a
+// snapshot cache key); callers should treat
+// them as opaque and always construct them with NewSubject rather than
+// concatenating fields themselves.
+type Subject string
+
+// subjecter is implemented by structs.Event payloads that know the natural
+// key they belong to (a job ID, node ID, ...). It returns a plain string
+// rather than a Subject so that nomad/structs, which this package already
+// imports, never needs to import nomad/stream back. Payloads that don't
+// implement it are treated as belonging to AllSubjects, so migrating a
+// payload type is additive and never silently drops events.
+type subjecter interface {
+	Subject() string
+}
+
+// NewSubject builds the Subject for a namespace/key pair. Cluster scoped
+// topics (e.g. Node) have no namespace and should pass "".
+func NewSubject(namespace, key string) Subject {
+	if key == "" {
+		return AllSubjects
+	}
+	if namespace == "" {
+		return Subject(key)
+	}
+	return Subject(namespace + "\x00" + key)
+}
+
+// eventSubject returns the Subject an event should be published and
+// buffered under.
+func eventSubject(e structs.Event) Subject {
+	if s, ok := e.Payload.(subjecter); ok {
+		return NewSubject(e.Namespace, s.Subject())
+	}
+	return AllSubjects
+}
+
+// eventKeySubject returns the namespace-less form of eventSubject: the
+// bucket a subscription with Namespace == "" for this event's key attaches
+// to, matching the namespace-wildcard convention filter() has always
+// supported (an empty SubscribeRequest.Namespace matches the key in any
+// namespace). EventBroker.Publish appends to this bucket in addition to
+// eventSubject's namespaced one so that convention keeps working now that
+// events are also routed by subject instead of landing in one shared
+// per-topic buffer.
+func eventKeySubject(e structs.Event) Subject {
+	if s, ok := e.Payload.(subjecter); ok {
+		return NewSubject("", s.Subject())
+	}
+	return AllSubjects
+}
+
+// subjectsFor returns the Subjects a SubscribeRequest's key filters for a
+// topic translate to. An empty key set yields just AllSubjects; a TopicAll
+// key adds AllSubjects (once, however many TopicAll keys there are)
+// alongside the subjects of the other keys. Other repeated keys are not
+// de-duplicated. req.Namespace == "" produces
+// the namespace-less form of each key (NewSubject("", k)), which is the
+// same bucket eventKeySubject populates on publish, so a subscriber
+// watching a key across every namespace still sees it.
+func subjectsFor(req *SubscribeRequest, topic structs.Topic) []Subject {
+	_, allTopics := req.Topics[structs.TopicAll]
+	keys := req.Topics[topic]
+	if allTopics {
+		keys = req.Topics[structs.TopicAll]
+	}
+
+	if len(keys) == 0 {
+		return []Subject{AllSubjects}
+	}
+
+	subjects := make([]Subject, 0, len(keys)+1)
+	seenAll := false
+	for _, k := range keys {
+		if k == string(structs.TopicAll) {
+			if !seenAll {
+				subjects = append(subjects, AllSubjects)
+				seenAll = true
+			}
+			continue
+		}
+		subjects = append(subjects, NewSubject(req.Namespace, k))
+	}
+	return subjects
+}
@@ -3,8 +3,10 @@ package stream
 import (
 	"context"
 	"errors"
+	"sync"
 	"sync/atomic"
 
+	"github.com/hashicorp/nomad/acl"
 	"github.com/hashicorp/nomad/nomad/structs"
 )
 
@@ -42,6 +44,16 @@ type Subscription struct {
 	// It must be safe to call the function from multiple goroutines and the function
 	// must be idempotent.
 	unsub func()
+
+	// aclResolver resolves req.Token to the Authorizer each event's
+	// payload is checked against. It is nil for subscriptions that were
+	// never given one (e.g. internal, already-trusted consumers), in
+	// which case ACL filtering is skipped entirely.
+	aclResolver ACLResolver
+
+	authzOnce sync.Once
+	authz     acl.Authorizer
+	authzErr  error
 }
 
 type SubscribeRequest struct {
@@ -58,13 +70,54 @@ type SubscribeRequest struct {
 	StartExactlyAtIndex bool
 }
 
-func newSubscription(req *SubscribeRequest, item *bufferItem, unsub func()) *Subscription {
+func newSubscription(req *SubscribeRequest, item *bufferItem, forceClosed chan struct{}, unsub func(), aclResolver ACLResolver) *Subscription {
 	return &Subscription{
-		forceClosed: make(chan struct{}),
+		forceClosed: forceClosed,
 		req:         req,
 		currentItem: item,
 		unsub:       unsub,
+		aclResolver: aclResolver,
+	}
+}
+
+// authorizer resolves and caches the Authorizer for the subscription's
+// token. It is only resolved once per subscription: a later change to the
+// token or its policies is handled by forceClose tearing the subscription
+// down rather than by re-resolving here.
+func (s *Subscription) authorizer() (acl.Authorizer, error) {
+	s.authzOnce.Do(func() {
+		if s.aclResolver == nil {
+			return
+		}
+		s.authz, s.authzErr = s.aclResolver.ResolveToken(s.req.Token)
+	})
+	return s.authz, s.authzErr
+}
+
+// filterACL drops events the subscription's token is not authorized to
+// read. Payloads that don't implement structs.Payload are passed through
+// unchanged, since they predate ACL-aware filtering.
+func (s *Subscription) filterACL(events []structs.Event) ([]structs.Event, error) {
+	if s.aclResolver == nil || len(events) == 0 {
+		return events, nil
+	}
+
+	authz, err := s.authorizer()
+	if err != nil {
+		return nil, err
+	}
+	if authz == nil {
+		return events, nil
+	}
+
+	filtered := make([]structs.Event, 0, len(events))
+	for _, e := range events {
+		payload, ok := e.Payload.(structs.Payload)
+		if !ok || payload.HasReadPermission(authz) {
+			filtered = append(filtered, e)
+		}
 	}
+	return filtered, nil
 }
 
 func (s *Subscription) Next(ctx context.Context) (structs.Events, error) {
@@ -82,10 +135,23 @@ func (s *Subscription) Next(ctx context.Context) (structs.Events, error) {
 		}
 		s.currentItem = next
 
+		for _, e := range next.Events.Events {
+			if e.Topic == endOfSnapshotTopic {
+				return structs.Events{Index: next.Events.Index, Events: []structs.Event{e}}, nil
+			}
+		}
+
 		events := filter(s.req, next.Events.Events)
 		if len(events) == 0 {
 			continue
 		}
+		events, err = s.filterACL(events)
+		if err != nil {
+			return structs.Events{}, err
+		}
+		if len(events) == 0 {
+			continue
+		}
 		return structs.Events{Index: next.Events.Index, Events: events}, nil
 	}
 }
@@ -96,16 +162,32 @@ func (s *Subscription) NextNoBlock() ([]structs.Event, error) {
 	}
 
 	for {
-		next := s.currentItem.NextNoBlock()
+		next, err := s.currentItem.NextNoBlock()
+		if err != nil {
+			return nil, err
+		}
 		if next == nil {
 			return nil, nil
 		}
 		s.currentItem = next
 
+		for _, e := range next.Events.Events {
+			if e.Topic == endOfSnapshotTopic {
+				return []structs.Event{e}, nil
+			}
+		}
+
 		events := filter(s.req, next.Events.Events)
 		if len(events) == 0 {
 			continue
 		}
+		events, err = s.filterACL(events)
+		if err != nil {
+			return nil, err
+		}
+		if len(events) == 0 {
+			continue
+		}
 		return events, nil
 	}
 }
@@ -0,0 +1,58 @@
+package stream
+
+import (
+	"testing"
+
+	"github.com/hashicorp/nomad/acl"
+	"github.com/hashicorp/nomad/nomad/structs"
+)
+
+// fakeACLResolver always resolves to a non-nil fakeAuthorizer; the fake
+// payload below ignores the authz argument entirely, so this is enough to
+// exercise filterACL's dispatch without needing a real acl.Authorizer.
+type fakeACLResolver struct{}
+
+func (fakeACLResolver) ResolveToken(token string) (acl.Authorizer, error) {
+	return fakeAuthorizer{}, nil
+}
+
+type fakeAuthorizer struct{}
+
+func (fakeAuthorizer) AllowNamespaceOperation(string, string) bool { return false }
+func (fakeAuthorizer) AllowNodeRead() bool                         { return false }
+
+type fakePayload struct {
+	allowed bool
+}
+
+func (p *fakePayload) HasReadPermission(acl.Authorizer) bool { return p.allowed }
+
+func TestSubscription_FilterACL(t *testing.T) {
+	broker := NewEventBroker(EventBrokerCfg{ACLResolver: fakeACLResolver{}})
+
+	sub, err := broker.Subscribe(&SubscribeRequest{
+		Token: "t",
+		Topics: map[structs.Topic][]string{
+			structs.Topic("Job"): {"web"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	broker.Publish(&structs.Events{Index: 1, Events: []structs.Event{
+		{Topic: structs.Topic("Job"), Key: "web", Payload: &fakePayload{allowed: false}},
+	}})
+	broker.Publish(&structs.Events{Index: 2, Events: []structs.Event{
+		{Topic: structs.Topic("Job"), Key: "web", Payload: &fakePayload{allowed: true}},
+	}})
+
+	events := waitForEvents(t, sub)
+	if len(events) != 1 {
+		t.Fatalf("expected exactly one event to survive ACL filtering, got %+v", events)
+	}
+	if p, ok := events[0].Payload.(*fakePayload); !ok || !p.allowed {
+		t.Fatalf("expected the allowed event, got %+v", events[0])
+	}
+}
@@ -0,0 +1,97 @@
+package structs
+
+import "github.com/hashicorp/nomad/acl"
+
+// JobEvent is the payload carried by Job topic events.
+type JobEvent struct {
+	JobID     string
+	Namespace string
+}
+
+// Subject returns the job ID, the natural key Job events are bucketed
+// under so a subscription watching one job never wakes for any other.
+func (e *JobEvent) Subject() string { return e.JobID }
+
+// HasReadPermission reports whether authz can read jobs in this event's
+// namespace.
+func (e *JobEvent) HasReadPermission(authz acl.Authorizer) bool {
+	return authz.AllowNamespaceOperation(e.Namespace, acl.NamespaceCapabilityReadJob)
+}
+
+// EvalEvent is the payload carried by Evaluation topic events.
+type EvalEvent struct {
+	EvalID    string
+	JobID     string
+	Namespace string
+}
+
+// Subject buckets Evaluation events under the job they evaluate.
+func (e *EvalEvent) Subject() string { return e.JobID }
+
+// HasReadPermission reports whether authz can read the job this
+// evaluation belongs to - evaluations carry no information beyond that.
+func (e *EvalEvent) HasReadPermission(authz acl.Authorizer) bool {
+	return authz.AllowNamespaceOperation(e.Namespace, acl.NamespaceCapabilityReadJob)
+}
+
+// AllocEvent is the payload carried by Allocation topic events.
+type AllocEvent struct {
+	AllocID   string
+	JobID     string
+	Namespace string
+}
+
+// Subject buckets Allocation events under the job they belong to.
+func (e *AllocEvent) Subject() string { return e.JobID }
+
+// HasReadPermission reports whether authz can read the job this
+// allocation belongs to.
+func (e *AllocEvent) HasReadPermission(authz acl.Authorizer) bool {
+	return authz.AllowNamespaceOperation(e.Namespace, acl.NamespaceCapabilityReadJob)
+}
+
+// DeploymentEvent is the payload carried by Deployment topic events.
+type DeploymentEvent struct {
+	DeploymentID string
+	JobID        string
+	Namespace    string
+}
+
+// Subject buckets Deployment events under the job being deployed.
+func (e *DeploymentEvent) Subject() string { return e.JobID }
+
+// HasReadPermission reports whether authz can read the job this
+// deployment belongs to.
+func (e *DeploymentEvent) HasReadPermission(authz acl.Authorizer) bool {
+	return authz.AllowNamespaceOperation(e.Namespace, acl.NamespaceCapabilityReadJob)
+}
+
+// NodeEvent is the payload carried by Node topic events. Nodes are
+// cluster scoped, not namespaced.
+type NodeEvent struct {
+	NodeID string
+}
+
+// Subject returns the node ID.
+func (e *NodeEvent) Subject() string { return e.NodeID }
+
+// HasReadPermission reports whether authz has node:read.
+func (e *NodeEvent) HasReadPermission(authz acl.Authorizer) bool {
+	return authz.AllowNodeRead()
+}
+
+// ServiceEvent is the payload carried by Service topic events.
+type ServiceEvent struct {
+	ServiceName string
+	Namespace   string
+}
+
+// Subject returns the service name.
+func (e *ServiceEvent) Subject() string { return e.ServiceName }
+
+// HasReadPermission reports whether authz can read jobs in this event's
+// namespace - services are only exposed alongside the job that registers
+// them.
+func (e *ServiceEvent) HasReadPermission(authz acl.Authorizer) bool {
+	return authz.AllowNamespaceOperation(e.Namespace, acl.NamespaceCapabilityReadJob)
+}
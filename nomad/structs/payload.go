@@ -0,0 +1,12 @@
+package structs
+
+import "github.com/hashicorp/nomad/acl"
+
+// Payload is implemented by Event payload types that can tell whether a
+// given token is still allowed to read them. Subscription.Next uses it to
+// drop events a subscriber's ACL token doesn't cover, even when the
+// subscription itself was established under a more permissive token whose
+// policies have since been narrowed.
+type Payload interface {
+	HasReadPermission(authz acl.Authorizer) bool
+}